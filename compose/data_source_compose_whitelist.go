@@ -0,0 +1,97 @@
+package compose
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/ustream/terraform-provider-compose/composeapi"
+)
+
+func dataSourceComposeWhitelist() *schema.Resource {
+	log.Printf("[DEBUG] Setting up data source compose_whitelist")
+	return &schema.Resource{
+		Read: dataSourceComposeWhitelistRead,
+
+		Schema: map[string]*schema.Schema{
+			"deployment_id": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"ip": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"entries": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceComposeWhitelistRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Get("deployment_id").(string)
+	ip := d.Get("ip").(string)
+
+	whitelist, errs := client.GetWhitelistForDeployment(deploymentID)
+	if errs != nil {
+		return fmt.Errorf("Error querying whitelist entries: %s", errs)
+	}
+
+	var cidr string
+	if ip != "" {
+		var err error
+		cidr, err = composeapi.CanonicalWhitelistCIDR(ip)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries := make([]map[string]interface{}, 0, len(whitelist.Embedded.Whitelist))
+	for _, whitelistEntry := range whitelist.Embedded.Whitelist {
+		if cidr != "" {
+			entryCIDR, err := composeapi.CanonicalWhitelistCIDR(whitelistEntry.IP)
+			if err != nil || entryCIDR != cidr {
+				continue
+			}
+		}
+
+		entries = append(entries, map[string]interface{}{
+			"id":          whitelistEntry.ID,
+			"ip":          whitelistEntry.IP,
+			"description": whitelistEntry.Description,
+		})
+	}
+
+	if ip != "" && len(entries) == 0 {
+		return fmt.Errorf("No whitelist entry found for IP %s on deployment %s", ip, deploymentID)
+	}
+
+	d.Set("entries", entries)
+	d.SetId(fmt.Sprintf("%s-whitelist", deploymentID))
+
+	return nil
+}
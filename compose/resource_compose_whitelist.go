@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net"
 	"strings"
 	"time"
 
@@ -18,6 +17,7 @@ func resourceComposeWhitelist() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceComposeWhitelistCreate,
 		Read:   resourceComposeWhitelistRead,
+		Update: resourceComposeWhitelistUpdate,
 		Delete: resourceComposeWhitelistDelete,
 		Importer: &schema.ResourceImporter{
 			State: resourceComposeWhitelistImport,
@@ -28,25 +28,23 @@ func resourceComposeWhitelist() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 				ValidateFunc: func(val interface{}, field string) (warnings []string, errors []error) {
-					value := val.(string)
-					if _, _, err := net.ParseCIDR(value); err != nil {
-						errors = append(
-							errors,
-							fmt.Errorf(
-								"Provided value '(%s)' is not a valid IPv4 network: %s",
-								value,
-								err,
-							),
-						)
+					if _, err := composeapi.CanonicalWhitelistCIDR(val.(string)); err != nil {
+						errors = append(errors, err)
 					}
 					return
 				},
+				// The API always echoes back a canonicalized CIDR (e.g. "10.0.0.5/32" for a
+				// configured "10.0.0.5"), which Read stores into state. Without this, a bare
+				// address in config would diff against state forever and, since ip is
+				// ForceNew, recreate the whitelist entry on every apply.
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return composeapi.WhitelistCIDRsEqual(old, new)
+				},
 			},
 
 			"description": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"deployment_id": {
@@ -54,6 +52,11 @@ func resourceComposeWhitelist() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+
+			"normalized_cidr": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
@@ -64,10 +67,14 @@ func resourceComposeWhitelistCreate(d *schema.ResourceData, meta interface{}) er
 	client := meta.(*composeapi.Client)
 
 	deploymentID := d.Get("deployment_id").(string)
-	ip := d.Get("ip").(string)
 	description := d.Get("description").(string)
 
-	whitelist := composeapi.Whitelist{IP: ip, Description: description}
+	cidr, err := composeapi.CanonicalWhitelistCIDR(d.Get("ip").(string))
+	if err != nil {
+		return err
+	}
+
+	whitelist := composeapi.Whitelist{IP: cidr, Description: description}
 
 	_, errs := client.AddWhitelistForDeployment(deploymentID, whitelist)
 
@@ -75,17 +82,16 @@ func resourceComposeWhitelistCreate(d *schema.ResourceData, meta interface{}) er
 		return fmt.Errorf("Error adding whitelist entry: %s", errs)
 	}
 
-	stateChangeConf := &resource.StateChangeConf{
-		Pending:    []string{},
-		Target:     []string{"existing"},
-		Refresh:    whitelistCompletedRefreshFunc(client, deploymentID, ip),
-		Timeout:    5 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+	waiter := &composeapi.OperationWaiter{
+		Client:       client,
+		Kind:         composeapi.OpWhitelistExists,
+		DeploymentID: deploymentID,
+		Target:       cidr,
+		Pending:      []string{},
+		TargetStates: []string{"existing"},
 	}
 
-	_, err := stateChangeConf.WaitForState()
-	if err != nil {
+	if _, err := waiter.Conf(5*time.Minute, 10*time.Second, 3*time.Second).WaitForState(); err != nil {
 		return err
 	}
 
@@ -120,6 +126,11 @@ func resourceComposeWhitelistRead(d *schema.ResourceData, meta interface{}) erro
 		if whitelistEntry.ID == d.Id() {
 			d.Set("description", whitelistEntry.Description)
 			d.Set("ip", whitelistEntry.IP)
+			normalized := whitelistEntry.IP
+			if cidr, err := composeapi.CanonicalWhitelistCIDR(whitelistEntry.IP); err == nil {
+				normalized = cidr
+			}
+			d.Set("normalized_cidr", normalized)
 			return nil
 		}
 	}
@@ -129,28 +140,51 @@ func resourceComposeWhitelistRead(d *schema.ResourceData, meta interface{}) erro
 	return nil
 }
 
+func resourceComposeWhitelistUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	if !d.HasChange("description") {
+		return resourceComposeWhitelistRead(d, meta)
+	}
+
+	whitelist := composeapi.Whitelist{
+		IP:          d.Get("ip").(string),
+		Description: d.Get("description").(string),
+	}
+
+	if errs := client.UpdateWhitelistForDeployment(d.Get("deployment_id").(string), d.Id(), whitelist); errs != nil {
+		return fmt.Errorf("Error updating whitelist entry: %s", errs)
+	}
+
+	return resourceComposeWhitelistRead(d, meta)
+}
+
 func resourceComposeWhitelistDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*composeapi.Client)
 
 	deploymentID := d.Get("deployment_id").(string)
 
+	cidr, err := composeapi.CanonicalWhitelistCIDR(d.Get("ip").(string))
+	if err != nil {
+		return err
+	}
+
 	_, errs := client.DeleteWhitelistForDeployment(deploymentID, d.Id())
 
 	if errs != nil {
 		return fmt.Errorf("Error deleting whitelist entry: %s", errs)
 	}
 
-	stateChangeConf := &resource.StateChangeConf{
-		Pending:    []string{"existing"},
-		Target:     []string{},
-		Refresh:    whitelistCompletedRefreshFunc(client, deploymentID, d.Get("ip").(string)),
-		Timeout:    5 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+	waiter := &composeapi.OperationWaiter{
+		Client:       client,
+		Kind:         composeapi.OpWhitelistGone,
+		DeploymentID: deploymentID,
+		Target:       cidr,
+		Pending:      []string{"existing"},
+		TargetStates: []string{},
 	}
 
-	_, err := stateChangeConf.WaitForState()
-	if err != nil {
+	if _, err := waiter.Conf(5*time.Minute, 10*time.Second, 3*time.Second).WaitForState(); err != nil {
 		return err
 	}
 
@@ -167,31 +201,17 @@ func jobCompletedRefreshFunc(client *composeapi.Client, deploymentid string, rec
 	}
 }
 
-func whitelistCompletedRefreshFunc(client *composeapi.Client, deploymentid string, whitelistip string) resource.StateRefreshFunc {
-	return func() (interface{}, string, error) {
-		whitelist, err := client.GetWhitelistForDeployment(deploymentid)
-		if err != nil {
-			return nil, "", err[0]
-		}
-		log.Printf("[DEBUG] Checking whitelist match: %s in %v", whitelistip, whitelist.Embedded.Whitelist)
-		for _, whitelistEntry := range whitelist.Embedded.Whitelist {
-
-			if whitelistEntry.IP == whitelistip {
-				log.Printf("[DEBUG] Match found")
-				return whitelistEntry.ID, "existing", nil
-			}
-		}
-		log.Printf("[DEBUG] Match not found")
-		return nil, "", nil
-	}
-}
-
 func resourceComposeWhitelistImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
 
 	client := meta.(*composeapi.Client)
 	s := strings.Split(d.Id(), "@")
 	deploymentID, ip := s[0], s[1]
 
+	cidr, err := composeapi.CanonicalWhitelistCIDR(ip)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Printf("[DEBUG] DeploymentID: %s IP: %s", deploymentID, ip)
 	whitelist, errs := client.GetWhitelistForDeployment(deploymentID)
 
@@ -201,11 +221,13 @@ func resourceComposeWhitelistImport(d *schema.ResourceData, meta interface{}) ([
 
 	log.Printf("[DEBUG] Checking whitelist %v", whitelist)
 	for _, whitelistEntry := range whitelist.Embedded.Whitelist {
-		if whitelistEntry.IP == ip {
+		entryCIDR, err := composeapi.CanonicalWhitelistCIDR(whitelistEntry.IP)
+		if err == nil && entryCIDR == cidr {
 			results := make([]*schema.ResourceData, 1)
 			d.Set("deployment_id", deploymentID)
 			d.Set("description", whitelistEntry.Description)
 			d.Set("ip", whitelistEntry.IP)
+			d.Set("normalized_cidr", entryCIDR)
 			d.SetId(whitelistEntry.ID)
 			results[0] = d
 			log.Printf("[DEBUG] Found match %v", d)
@@ -0,0 +1,237 @@
+package compose
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/ustream/terraform-provider-compose/composeapi"
+)
+
+func resourceComposeWhitelistSet() *schema.Resource {
+	log.Printf("[DEBUG] Setting up resource compose_whitelist_set")
+	return &schema.Resource{
+		Create: resourceComposeWhitelistSetCreate,
+		Read:   resourceComposeWhitelistSetRead,
+		Update: resourceComposeWhitelistSetUpdate,
+		Delete: resourceComposeWhitelistSetDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceComposeWhitelistSetImport,
+		},
+		Schema: map[string]*schema.Schema{
+			"deployment_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"entry": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Set:      resourceComposeWhitelistSetEntryHash,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip": {
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateFunc: func(val interface{}, field string) (warnings []string, errors []error) {
+								if _, err := composeapi.CanonicalWhitelistCIDR(val.(string)); err != nil {
+									errors = append(errors, err)
+								}
+								return
+							},
+						},
+
+						"description": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceComposeWhitelistSetEntryHash hashes an entry by the canonical form of its IP
+// only, so that reordering the `entry` blocks in config never produces a diff, and
+// "10.0.0.5" hashes the same as "10.0.0.5/32".
+func resourceComposeWhitelistSetEntryHash(v interface{}) int {
+	m := v.(map[string]interface{})
+	ip := m["ip"].(string)
+	if cidr, err := composeapi.CanonicalWhitelistCIDR(ip); err == nil {
+		ip = cidr
+	}
+	return hashcode.String(ip)
+}
+
+func resourceComposeWhitelistSetCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Get("deployment_id").(string)
+
+	if err := reconcileWhitelistSet(client, deploymentID, d.Get("entry").(*schema.Set)); err != nil {
+		return err
+	}
+
+	d.SetId(deploymentID)
+
+	return resourceComposeWhitelistSetRead(d, meta)
+}
+
+func resourceComposeWhitelistSetRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Get("deployment_id").(string)
+
+	whitelist, errs := client.GetWhitelistForDeployment(deploymentID)
+	if errs != nil {
+		return fmt.Errorf("Error querying whitelist entries: %s", errs)
+	}
+
+	d.Set("entry", schema.NewSet(resourceComposeWhitelistSetEntryHash, flattenWhitelistEntries(whitelist)))
+
+	return nil
+}
+
+func resourceComposeWhitelistSetUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Get("deployment_id").(string)
+
+	if err := reconcileWhitelistSet(client, deploymentID, d.Get("entry").(*schema.Set)); err != nil {
+		return err
+	}
+
+	return resourceComposeWhitelistSetRead(d, meta)
+}
+
+func resourceComposeWhitelistSetDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Get("deployment_id").(string)
+
+	return reconcileWhitelistSet(client, deploymentID, schema.NewSet(resourceComposeWhitelistSetEntryHash, nil))
+}
+
+func resourceComposeWhitelistSetImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*composeapi.Client)
+
+	deploymentID := d.Id()
+
+	whitelist, errs := client.GetWhitelistForDeployment(deploymentID)
+	if errs != nil {
+		return nil, fmt.Errorf("Error querying whitelist entries: %s", errs)
+	}
+
+	d.Set("deployment_id", deploymentID)
+	d.Set("entry", schema.NewSet(resourceComposeWhitelistSetEntryHash, flattenWhitelistEntries(whitelist)))
+	d.SetId(deploymentID)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func flattenWhitelistEntries(whitelist *composeapi.Whitelists) []interface{} {
+	entries := make([]interface{}, 0, len(whitelist.Embedded.Whitelist))
+	for _, whitelistEntry := range whitelist.Embedded.Whitelist {
+		entries = append(entries, map[string]interface{}{
+			"ip":          whitelistEntry.IP,
+			"description": whitelistEntry.Description,
+		})
+	}
+	return entries
+}
+
+// reconcileWhitelistSet diffs the configured entries against the deployment's current
+// whitelist and issues the Add/Update/Delete calls needed to make the server match,
+// waiting for each add/remove to settle before moving on to the next one. Entries are
+// matched by canonical CIDR, not the raw configured string, since the server always
+// echoes back a canonicalized IP (e.g. "10.0.0.5/32" for a configured "10.0.0.5").
+func reconcileWhitelistSet(client *composeapi.Client, deploymentID string, configured *schema.Set) error {
+	current, errs := client.GetWhitelistForDeployment(deploymentID)
+	if errs != nil {
+		return fmt.Errorf("Error querying whitelist entries: %s", errs)
+	}
+
+	byCIDR := make(map[string]composeapi.WhitelistEntry, len(current.Embedded.Whitelist))
+	for _, whitelistEntry := range current.Embedded.Whitelist {
+		cidr, err := composeapi.CanonicalWhitelistCIDR(whitelistEntry.IP)
+		if err != nil {
+			cidr = whitelistEntry.IP
+		}
+		byCIDR[cidr] = whitelistEntry
+	}
+
+	wantCIDRs := make(map[string]bool, configured.Len())
+	for _, raw := range configured.List() {
+		m := raw.(map[string]interface{})
+		description := m["description"].(string)
+
+		cidr, err := composeapi.CanonicalWhitelistCIDR(m["ip"].(string))
+		if err != nil {
+			return err
+		}
+		wantCIDRs[cidr] = true
+
+		existing, ok := byCIDR[cidr]
+		if !ok {
+			whitelist := composeapi.Whitelist{IP: cidr, Description: description}
+			if _, errs := client.AddWhitelistForDeployment(deploymentID, whitelist); errs != nil {
+				return fmt.Errorf("Error adding whitelist entry: %s", errs)
+			}
+
+			if err := waitForWhitelistEntry(client, deploymentID, cidr, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if existing.Description == description {
+			continue
+		}
+
+		whitelist := composeapi.Whitelist{IP: existing.IP, Description: description}
+		if errs := client.UpdateWhitelistForDeployment(deploymentID, existing.ID, whitelist); errs != nil {
+			return fmt.Errorf("Error updating whitelist entry: %s", errs)
+		}
+	}
+
+	for cidr, whitelistEntry := range byCIDR {
+		if wantCIDRs[cidr] {
+			continue
+		}
+
+		if _, errs := client.DeleteWhitelistForDeployment(deploymentID, whitelistEntry.ID); errs != nil {
+			return fmt.Errorf("Error deleting whitelist entry: %s", errs)
+		}
+
+		if err := waitForWhitelistEntry(client, deploymentID, cidr, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func waitForWhitelistEntry(client *composeapi.Client, deploymentID, ip string, present bool) error {
+	kind := composeapi.OpWhitelistExists
+	pending, target := []string{}, []string{"existing"}
+	if !present {
+		kind = composeapi.OpWhitelistGone
+		pending, target = []string{"existing"}, []string{}
+	}
+
+	waiter := &composeapi.OperationWaiter{
+		Client:       client,
+		Kind:         kind,
+		DeploymentID: deploymentID,
+		Target:       ip,
+		Pending:      pending,
+		TargetStates: target,
+	}
+
+	_, err := waiter.Conf(5*time.Minute, 10*time.Second, 3*time.Second).WaitForState()
+	return err
+}
@@ -0,0 +1,91 @@
+package composeapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+// OperationKind identifies which long-running Compose operation an OperationWaiter
+// polls for.
+type OperationKind int
+
+const (
+	// OpRecipe waits for a recipe (deployment job) to reach a terminal status.
+	OpRecipe OperationKind = iota
+	// OpWhitelistExists waits for a whitelist entry to appear on a deployment.
+	OpWhitelistExists
+	// OpWhitelistGone waits for a whitelist entry to disappear from a deployment.
+	OpWhitelistGone
+)
+
+// waiterClient is the subset of *Client that OperationWaiter needs, factored out so
+// tests can exercise RefreshFunc/Conf against a fake instead of a live API client.
+type waiterClient interface {
+	GetRecipe(deploymentID, recipeID string) (*Recipe, []error)
+	GetWhitelistForDeployment(deploymentID string) (*Whitelists, []error)
+}
+
+// OperationWaiter polls the Compose API until a long-running operation reaches one of
+// TargetStates. It replaces the ad-hoc StateChangeConf/refresh-func pairs that used to
+// be hand-rolled by each resource, so new resources (deployments, users, backups, ...)
+// can reuse it instead of growing their own.
+type OperationWaiter struct {
+	Client waiterClient
+	Kind   OperationKind
+
+	DeploymentID string
+	RecipeID     string
+	Target       string // e.g. the whitelist IP being waited on
+
+	Pending      []string
+	TargetStates []string
+}
+
+// RefreshFunc returns the resource.StateRefreshFunc appropriate for Kind.
+func (w *OperationWaiter) RefreshFunc() resource.StateRefreshFunc {
+	switch w.Kind {
+	case OpRecipe:
+		return w.refreshRecipe
+	case OpWhitelistExists, OpWhitelistGone:
+		return w.refreshWhitelist
+	default:
+		panic(fmt.Sprintf("composeapi: unknown OperationKind %d", w.Kind))
+	}
+}
+
+// Conf builds a resource.StateChangeConf wired up to RefreshFunc.
+func (w *OperationWaiter) Conf(timeout, delay, minTimeout time.Duration) *resource.StateChangeConf {
+	return &resource.StateChangeConf{
+		Pending:    w.Pending,
+		Target:     w.TargetStates,
+		Refresh:    w.RefreshFunc(),
+		Timeout:    timeout,
+		Delay:      delay,
+		MinTimeout: minTimeout,
+	}
+}
+
+func (w *OperationWaiter) refreshRecipe() (interface{}, string, error) {
+	recipe, err := w.Client.GetRecipe(w.DeploymentID, w.RecipeID)
+	if err != nil {
+		return nil, "", err[0]
+	}
+	return recipe.ID, recipe.Status, nil
+}
+
+func (w *OperationWaiter) refreshWhitelist() (interface{}, string, error) {
+	whitelist, err := w.Client.GetWhitelistForDeployment(w.DeploymentID)
+	if err != nil {
+		return nil, "", err[0]
+	}
+
+	for _, whitelistEntry := range whitelist.Embedded.Whitelist {
+		if WhitelistCIDRsEqual(whitelistEntry.IP, w.Target) {
+			return whitelistEntry.ID, "existing", nil
+		}
+	}
+
+	return nil, "", nil
+}
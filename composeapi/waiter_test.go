@@ -0,0 +1,116 @@
+package composeapi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeWaiterClient is a waiterClient that returns canned responses instead of calling the
+// real API, so OperationWaiter's state-transition and timeout behavior can be tested
+// without a live Compose deployment.
+type fakeWaiterClient struct {
+	recipes      []*Recipe
+	recipeErr    []error
+	whitelists   []*Whitelists
+	whitelistErr []error
+	call         int
+}
+
+func (f *fakeWaiterClient) GetRecipe(deploymentID, recipeID string) (*Recipe, []error) {
+	i := f.call
+	f.call++
+	if i < len(f.recipeErr) && f.recipeErr[i] != nil {
+		return nil, []error{f.recipeErr[i]}
+	}
+	if i >= len(f.recipes) {
+		i = len(f.recipes) - 1
+	}
+	return f.recipes[i], nil
+}
+
+func (f *fakeWaiterClient) GetWhitelistForDeployment(deploymentID string) (*Whitelists, []error) {
+	i := f.call
+	f.call++
+	if i < len(f.whitelistErr) && f.whitelistErr[i] != nil {
+		return nil, []error{f.whitelistErr[i]}
+	}
+	if i >= len(f.whitelists) {
+		i = len(f.whitelists) - 1
+	}
+	return f.whitelists[i], nil
+}
+
+func TestOperationWaiterRecipeSuccess(t *testing.T) {
+	recipe := &Recipe{}
+	recipe.ID = "r1"
+	recipe.Status = "complete"
+
+	waiter := &OperationWaiter{
+		Client:       &fakeWaiterClient{recipes: []*Recipe{recipe}},
+		Kind:         OpRecipe,
+		DeploymentID: "d1",
+		RecipeID:     "r1",
+		Pending:      []string{"queued", "executing"},
+		TargetStates: []string{"complete"},
+	}
+
+	state, err := waiter.Conf(time.Second, time.Millisecond, time.Millisecond).WaitForState()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state.(string) != "r1" {
+		t.Fatalf("expected state %q, got %q", "r1", state)
+	}
+}
+
+func TestOperationWaiterRecipeErrorPropagates(t *testing.T) {
+	waiter := &OperationWaiter{
+		Client:       &fakeWaiterClient{recipeErr: []error{errors.New("boom")}},
+		Kind:         OpRecipe,
+		DeploymentID: "d1",
+		RecipeID:     "r1",
+		Pending:      []string{"queued"},
+		TargetStates: []string{"complete"},
+	}
+
+	if _, err := waiter.Conf(time.Second, time.Millisecond, time.Millisecond).WaitForState(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestOperationWaiterWhitelistExistsMatchesCanonicalCIDR(t *testing.T) {
+	whitelist := &Whitelists{}
+	whitelist.Embedded.Whitelist = []WhitelistEntry{{ID: "w1", IP: "10.0.0.5/32", Description: "office"}}
+
+	waiter := &OperationWaiter{
+		Client:       &fakeWaiterClient{whitelists: []*Whitelists{whitelist}},
+		Kind:         OpWhitelistExists,
+		DeploymentID: "d1",
+		Target:       "10.0.0.5",
+		Pending:      []string{},
+		TargetStates: []string{"existing"},
+	}
+
+	if _, err := waiter.Conf(time.Second, time.Millisecond, time.Millisecond).WaitForState(); err != nil {
+		t.Fatalf("expected bare address to match canonical CIDR, got error: %s", err)
+	}
+}
+
+func TestOperationWaiterWhitelistGoneTimesOut(t *testing.T) {
+	whitelist := &Whitelists{}
+	whitelist.Embedded.Whitelist = []WhitelistEntry{{ID: "w1", IP: "10.0.0.5/32", Description: "office"}}
+
+	waiter := &OperationWaiter{
+		Client:       &fakeWaiterClient{whitelists: []*Whitelists{whitelist}},
+		Kind:         OpWhitelistGone,
+		DeploymentID: "d1",
+		Target:       "10.0.0.5",
+		Pending:      []string{"existing"},
+		TargetStates: []string{},
+	}
+
+	if _, err := waiter.Conf(10*time.Millisecond, time.Millisecond, time.Millisecond).WaitForState(); err == nil {
+		t.Fatal("expected timeout error since the entry never disappears, got nil")
+	}
+}
@@ -0,0 +1,40 @@
+package composeapi
+
+import (
+	"fmt"
+	"net"
+)
+
+// CanonicalWhitelistCIDR normalizes a whitelist "ip" value for storage and comparison.
+// Bare addresses (e.g. "10.0.0.5" or "::1") are widened to a single-host CIDR block
+// ("10.0.0.5/32", "::1/128"); values that are already CIDR blocks are reduced to their
+// network address, so that two equivalent values always compare equal regardless of
+// which host bits the caller happened to supply.
+func CanonicalWhitelistCIDR(value string) (string, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		if ip.To4() != nil {
+			return fmt.Sprintf("%s/32", ip.String()), nil
+		}
+		return fmt.Sprintf("%s/128", ip.String()), nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a valid IP address or CIDR block: %s", value, err)
+	}
+
+	return ipNet.String(), nil
+}
+
+// WhitelistCIDRsEqual reports whether two whitelist "ip" values denote the same address
+// or network, tolerating e.g. "10.0.0.5" from config matching "10.0.0.5/32" from the API.
+func WhitelistCIDRsEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	aCIDR, aErr := CanonicalWhitelistCIDR(a)
+	bCIDR, bErr := CanonicalWhitelistCIDR(b)
+
+	return aErr == nil && bErr == nil && aCIDR == bCIDR
+}
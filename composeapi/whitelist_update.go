@@ -0,0 +1,17 @@
+package composeapi
+
+import "fmt"
+
+// UpdateWhitelistForDeployment updates an existing whitelist entry's description in
+// place via PATCH, so operators can correct a typo without removing and re-adding the
+// IP (which would leave the deployment briefly unprotected).
+func (client *Client) UpdateWhitelistForDeployment(deploymentID string, whitelistID string, whitelist Whitelist) []error {
+	path := fmt.Sprintf("deployments/%s/whitelist/%s", deploymentID, whitelistID)
+
+	var updated WhitelistEntry
+	if err := client.patch(path, whitelist, &updated); err != nil {
+		return []error{err}
+	}
+
+	return nil
+}